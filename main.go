@@ -1,49 +1,41 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"log"
 	"os"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
-	apps "k8s.io/api/apps/v1beta2"
-	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/strategicpatch"
-	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
-)
-
-type ChangeType struct {
-	Type string
-	Name string
-}
+	ctrl "sigs.k8s.io/controller-runtime"
 
-type Deployment struct {
-	Name    string
-	Options meta.GetOptions
-}
+	"github.com/nlf/configmaster/pkg/configwatch"
+	"github.com/nlf/configmaster/pkg/controller"
+	"github.com/nlf/configmaster/pkg/workloads"
+)
 
 var (
-	namespace    = "default"
-	changeTimers = map[ChangeType]*time.Timer{}
-	updateTimers = map[Deployment]*time.Timer{}
-	delay        = time.Duration(5) * time.Second
-
-	client *kubernetes.Clientset
-	config *rest.Config
+	// namespaces is the set of namespaces configmaster watches; empty means
+	// every namespace in the cluster.
+	namespaces       []string
+	resourceSelector labels.Selector
+	autoScan         = false
+	delay            = time.Duration(5) * time.Second
+
+	config  *rest.Config
+	watches = configwatch.NewIndex()
 )
 
 func main() {
-	ns := os.Getenv("CONFIGMASTER_NAMESPACE")
-	if ns != "" {
-		namespace = ns
-	}
+	ctx := ctrl.SetupSignalHandler()
 
 	d := os.Getenv("CONFIGMASTER_DELAY")
 	if d != "" {
@@ -55,6 +47,23 @@ func main() {
 		delay = time.Duration(di) * time.Second
 	}
 
+	as := os.Getenv("CONFIGMASTER_AUTO_SCAN")
+	if as != "" {
+		var err error
+		autoScan, err = strconv.ParseBool(as)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if rs := os.Getenv("CONFIGMASTER_RESOURCE_SELECTOR"); rs != "" {
+		var err error
+		resourceSelector, err = labels.Parse(rs)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	var err error
 	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" && os.Getenv("KUBERNETES_SERVICE_PORT") != "" {
 		config, err = rest.InClusterConfig()
@@ -71,161 +80,142 @@ func main() {
 		}
 	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-
-	client = kubernetes.NewForConfigOrDie(config)
-	log.Printf("configmaster connecting to %s listening on namespace %s with %.0fs delay", config.Host, namespace, delay.Seconds())
+	client := kubernetes.NewForConfigOrDie(config)
+	namespaces = resolveNamespaces(ctx, client)
 
-	filterFunc := func(in watch.Event) (watch.Event, bool) {
-		return in, in.Type == "MODIFIED"
+	restarters := []workloads.Restarter{
+		&workloads.DeploymentRestarter{Client: client},
+		&workloads.StatefulSetRestarter{Client: client},
+		&workloads.DaemonSetRestarter{Client: client},
 	}
+	restarters = append(restarters, extraRestarters(config)...)
+	registry := workloads.NewRegistry(restarters...)
 
-	go func() {
-		configmaps, err := client.CoreV1().ConfigMaps(namespace).Watch(meta.ListOptions{})
-		if err != nil {
-			panic(err)
-		}
+	var metadataClient metadata.Interface
+	if autoScan {
+		log.Printf("CONFIGMASTER_AUTO_SCAN set, scanning every workload on each ConfigMap/Secret change")
+	} else {
+		metadataClient = metadata.NewForConfigOrDie(config)
 
-		for event := range watch.Filter(configmaps, filterFunc).ResultChan() {
-			configmap, _ := event.Object.(*core.ConfigMap)
-			delayedUpdate(ChangeType{
-				Type: "ConfigMap",
-				Name: configmap.ObjectMeta.Name,
-			})
+		dynamicClient := dynamic.NewForConfigOrDie(config)
+		for _, ns := range watchedNamespaces() {
+			go configwatch.Run(ctx, dynamicClient, ns, watches)
 		}
-	}()
+	}
 
-	go func() {
-		secrets, err := client.CoreV1().Secrets(namespace).Watch(meta.ListOptions{})
-		if err != nil {
-			panic(err)
-		}
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
+		Cache:                   controller.NewCacheOptions(namespaces, resourceSelector),
+		LeaderElection:          true,
+		LeaderElectionID:        "configmaster-leader-election",
+		LeaderElectionNamespace: leaderElectionNamespace(),
+	})
+	if err != nil {
+		panic(err)
+	}
 
-		for event := range watch.Filter(secrets, filterFunc).ResultChan() {
-			secret, _ := event.Object.(*core.Secret)
-			delayedUpdate(ChangeType{
-				Type: "Secret",
-				Name: secret.ObjectMeta.Name,
-			})
-		}
-	}()
+	if err := controller.AddToManager(mgr, controller.Options{
+		Registry:         registry,
+		Watches:          watches,
+		Metadata:         metadataClient,
+		AutoScan:         autoScan,
+		Delay:            delay,
+		ResourceSelector: os.Getenv("CONFIGMASTER_RESOURCE_SELECTOR"),
+	}); err != nil {
+		panic(err)
+	}
 
-	wg.Wait()
-}
+	log.Printf("configmaster connecting to %s listening on namespaces %s with %.0fs delay", config.Host, describeNamespaces(namespaces), delay.Seconds())
 
-func delayedUpdate(change ChangeType) {
-	if timer, ok := changeTimers[change]; ok {
-		log.Printf("saw change to %s %s, resetting delay", change.Type, change.Name)
-		timer.Reset(delay)
-	} else {
-		log.Printf("saw change to %s %s, starting countdown", change.Type, change.Name)
-		changeTimers[change] = time.AfterFunc(delay, func() {
-			delete(changeTimers, change)
-			findAndQueueDeployments(change)
-		})
+	if err := mgr.Start(ctx); err != nil {
+		panic(err)
 	}
 }
 
-func findAndQueueDeployments(change ChangeType) {
-	deployments, err := client.AppsV1beta2().Deployments(namespace).List(meta.ListOptions{})
-	if err != nil {
-		panic(err)
-	}
+// resolveNamespaces turns CONFIGMASTER_NAMESPACE, CONFIGMASTER_ALL_NAMESPACES
+// and CONFIGMASTER_NAMESPACE_SELECTOR into the list of namespaces to watch. An empty result means
+// every namespace in the cluster. Recognized forms:
+//
+//   - CONFIGMASTER_NAMESPACE=*                         -> all namespaces
+//   - CONFIGMASTER_ALL_NAMESPACES=true (NAMESPACE unset) -> all namespaces
+//   - CONFIGMASTER_NAMESPACE=a,b,c                     -> exactly [a, b, c]
+//   - CONFIGMASTER_NAMESPACE=a                         -> exactly [a]
+//   - (nothing set)                                    -> ["default"]
+//
+// CONFIGMASTER_NAMESPACE_SELECTOR, if set, is resolved against the
+// cluster's Namespace list and merged into the result; it has no effect
+// once the result is already "all namespaces".
+func resolveNamespaces(ctx context.Context, client kubernetes.Interface) []string {
+	ns := os.Getenv("CONFIGMASTER_NAMESPACE")
 
-deploymentLoop:
-	for _, deployment := range deployments.Items {
-		for _, container := range deployment.Spec.Template.Spec.Containers {
-			for _, env := range container.EnvFrom {
-				if change.Type == "ConfigMap" && env.ConfigMapRef != nil && env.ConfigMapRef.Name == change.Name {
-					queueDeployment(deployment)
-					continue deploymentLoop
-				}
+	allNamespaces := ns == "*"
+	if ns == "" {
+		all, err := strconv.ParseBool(os.Getenv("CONFIGMASTER_ALL_NAMESPACES"))
+		allNamespaces = err == nil && all
+	}
 
-				if change.Type == "Secret" && env.SecretRef != nil && env.SecretRef.Name == change.Name {
-					queueDeployment(deployment)
-					continue deploymentLoop
-				}
+	var result []string
+	switch {
+	case allNamespaces:
+		result = nil
+	case ns != "":
+		for _, n := range strings.Split(ns, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				result = append(result, n)
 			}
+		}
+	default:
+		result = []string{"default"}
+	}
 
-			for _, env := range container.Env {
-				if change.Type == "ConfigMap" && env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil && env.ValueFrom.ConfigMapKeyRef.Name == change.Name {
-					queueDeployment(deployment)
-					continue deploymentLoop
-				}
+	if sel := os.Getenv("CONFIGMASTER_NAMESPACE_SELECTOR"); sel != "" && !allNamespaces {
+		list, err := client.CoreV1().Namespaces().List(ctx, meta.ListOptions{LabelSelector: sel})
+		if err != nil {
+			panic(err)
+		}
 
-				if change.Type == "Secret" && env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == change.Name {
-					queueDeployment(deployment)
-					continue deploymentLoop
-				}
+		for _, n := range list.Items {
+			if !containsString(result, n.Name) {
+				result = append(result, n.Name)
 			}
 		}
 	}
-}
-
-func queueDeployment(deployment apps.Deployment) {
-	dep := Deployment{
-		Name: deployment.Name,
-		Options: meta.GetOptions{
-			ResourceVersion: deployment.ObjectMeta.ResourceVersion,
-		},
-	}
 
-	if timer, ok := updateTimers[dep]; ok {
-		log.Printf("resetting timer on queued update to deployment %s", deployment.Name)
-		timer.Reset(delay)
-	} else {
-		log.Printf("queuing update to deployment %s", deployment.Name)
-		updateTimers[dep] = time.AfterFunc(delay, func() {
-			delete(updateTimers, dep)
-			patchDeployment(dep)
-		})
-	}
+	return result
 }
 
-func patchDeployment(deployment Deployment) {
-	dep, err := client.AppsV1beta2().Deployments(namespace).Get(deployment.Name, deployment.Options)
-	if err != nil {
-		log.Printf("error retrieving deployment %s: %+v", deployment.Name, err)
-		return
-	}
-
-	current, err := json.Marshal(dep)
-	if err != nil {
-		log.Printf("error marshaling deployment %s: %+v", dep.Name, err)
-		return
+func leaderElectionNamespace() string {
+	if len(namespaces) == 1 {
+		return namespaces[0]
 	}
 
-	if dep.Annotations == nil {
-		dep.Annotations = map[string]string{}
-	}
+	return "default"
+}
 
-	if dep.Spec.Template.Annotations == nil {
-		dep.Spec.Template.Annotations = map[string]string{}
+func describeNamespaces(namespaces []string) string {
+	if len(namespaces) == 0 {
+		return "*"
 	}
 
-	now := time.Now().Format(time.RFC3339)
-
-	dep.Annotations["configmaster/last.update"] = now
-	dep.Spec.Template.Annotations["configmaster/last.update"] = now
+	return strings.Join(namespaces, ",")
+}
 
-	updated, err := json.Marshal(dep)
-	if err != nil {
-		log.Printf("error marshaling deployment %s: %+v", dep.Name, err)
-		return
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
 	}
 
-	patch, err := strategicpatch.CreateTwoWayMergePatch(current, updated, apps.Deployment{})
-	if err != nil {
-		log.Printf("error generating patch for deployment %s: %+v", dep.Name, err)
-		return
-	}
+	return false
+}
 
-	_, err = client.AppsV1beta2().Deployments(namespace).Patch(dep.Name, types.StrategicMergePatchType, patch)
-	if err != nil {
-		log.Printf("error patching deployment %s: %+v", dep.Name, err)
-		return
+// watchedNamespaces returns namespaces as-is, except when it's empty
+// (cluster-wide mode), where the dynamic client's convention is a single
+// "" namespace meaning every namespace.
+func watchedNamespaces() []string {
+	if len(namespaces) == 0 {
+		return []string{""}
 	}
 
-	log.Printf("patched deployment %s", dep.Name)
+	return namespaces
 }
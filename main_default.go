@@ -0,0 +1,16 @@
+//go:build !openshift
+// +build !openshift
+
+package main
+
+import (
+	"k8s.io/client-go/rest"
+
+	"github.com/nlf/configmaster/pkg/workloads"
+)
+
+// extraRestarters is a no-op in vanilla (non-OpenShift) builds; see
+// main_openshift.go for the "openshift" build-tagged counterpart.
+func extraRestarters(config *rest.Config) []workloads.Restarter {
+	return nil
+}
@@ -0,0 +1,25 @@
+//go:build openshift
+// +build openshift
+
+package main
+
+import (
+	osclient "github.com/openshift/client-go/apps/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/nlf/configmaster/pkg/workloads"
+)
+
+// extraRestarters adds the OpenShift-only DeploymentConfig Restarter when
+// configmaster is built with -tags openshift. The openshift client isn't
+// vendored into vanilla Kubernetes builds, so this lives behind the same
+// build tag as pkg/workloads/deploymentconfig.go rather than in main.go.
+func extraRestarters(config *rest.Config) []workloads.Restarter {
+	return []workloads.Restarter{
+		&workloads.DeploymentConfigRestarter{
+			Client:     osclient.NewForConfigOrDie(config),
+			CoreClient: kubernetes.NewForConfigOrDie(config),
+		},
+	}
+}
@@ -0,0 +1,170 @@
+package configwatch
+
+import (
+	"context"
+	"log"
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// List returns every ConfigWatch in namespace.
+func List(ctx context.Context, client dynamic.Interface, namespace string) ([]ConfigWatch, error) {
+	list, err := client.Resource(GroupVersionResource).Namespace(namespace).List(ctx, meta.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	watches := make([]ConfigWatch, 0, len(list.Items))
+	for _, item := range list.Items {
+		var cw ConfigWatch
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &cw); err != nil {
+			return nil, err
+		}
+
+		watches = append(watches, cw)
+	}
+
+	return watches, nil
+}
+
+// Event is a typed ConfigWatch change, mirroring watch.Event.
+type Event struct {
+	Type        watch.EventType
+	ConfigWatch ConfigWatch
+}
+
+// Watch streams ConfigWatch change events for namespace until ctx is
+// cancelled or the server closes the watch (timeout, apiserver restart,
+// ...), at which point the returned channel is closed. Watch itself makes
+// no attempt to reconnect; callers that need to stay in sync indefinitely
+// should use Run instead, which relists and re-watches automatically.
+func Watch(ctx context.Context, client dynamic.Interface, namespace string) (<-chan Event, error) {
+	w, err := client.Resource(GroupVersionResource).Namespace(namespace).Watch(ctx, meta.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer w.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+
+				u, ok := event.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+
+				var cw ConfigWatch
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &cw); err != nil {
+					continue
+				}
+
+				select {
+				case events <- Event{Type: event.Type, ConfigWatch: cw}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// Run keeps idx in sync with every ConfigWatch in namespace until ctx is
+// cancelled. Kubernetes watches are never permanent -- the apiserver closes
+// them on its own timeout, on a restart, or on any network hiccup -- and
+// Watch makes no attempt to reconnect, so without Run the ConfigWatch index
+// would silently freeze the first time that happened. Run relists (to pick
+// up anything missed while disconnected) and re-establishes the watch
+// whenever Watch's channel closes, backing off between attempts so a down
+// apiserver doesn't get hammered. It blocks; call it in a goroutine.
+func Run(ctx context.Context, client dynamic.Interface, namespace string, idx *Index) {
+	const maxBackoff = 30 * time.Second
+	backoff := time.Second
+
+	for ctx.Err() == nil {
+		existing, err := List(ctx, client, namespace)
+		if err != nil {
+			log.Printf("configwatch: listing ConfigWatches in %q failed, retrying in %s: %v", describeNamespace(namespace), backoff, err)
+			if !sleep(ctx, backoff) {
+				return
+			}
+
+			backoff = nextBackoff(backoff, maxBackoff)
+			continue
+		}
+
+		for _, cw := range existing {
+			idx.Put(cw)
+		}
+
+		events, err := Watch(ctx, client, namespace)
+		if err != nil {
+			log.Printf("configwatch: watching ConfigWatches in %q failed, retrying in %s: %v", describeNamespace(namespace), backoff, err)
+			if !sleep(ctx, backoff) {
+				return
+			}
+
+			backoff = nextBackoff(backoff, maxBackoff)
+			continue
+		}
+
+		backoff = time.Second
+		for event := range events {
+			switch event.Type {
+			case watch.Deleted:
+				idx.Delete(event.ConfigWatch.Namespace, event.ConfigWatch.Name)
+			default:
+				idx.Put(event.ConfigWatch)
+			}
+		}
+
+		// events closed: the watch ended (timeout, apiserver restart, ...).
+		// Loop around to relist and re-watch rather than leaving idx frozen.
+		if ctx.Err() == nil {
+			log.Printf("configwatch: watch of %q ended, relisting and re-watching", describeNamespace(namespace))
+		}
+	}
+}
+
+func describeNamespace(namespace string) string {
+	if namespace == "" {
+		return "*"
+	}
+
+	return namespace
+}
+
+func nextBackoff(current, max time.Duration) time.Duration {
+	next := current * 2
+	if next > max {
+		return max
+	}
+
+	return next
+}
+
+// sleep waits for d, returning false early if ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,129 @@
+package configwatch
+
+import (
+	"sync"
+
+	"github.com/nlf/configmaster/pkg/workloads"
+)
+
+// sourceKey identifies a ConfigMap or Secret by namespace, kind, and name.
+type sourceKey struct {
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+type target struct {
+	watchName string // owning ConfigWatch, so Delete can remove its entries on update/delete
+	ref       workloads.WorkloadRef
+	keys      []string
+	strategy  RestartStrategy
+}
+
+// Target is a Lookup result: the workload to roll out and how to roll it
+// out, as declared by the owning ConfigWatch's restartStrategy.
+type Target struct {
+	Ref             workloads.WorkloadRef
+	RestartStrategy RestartStrategy
+}
+
+// Index maps a ConfigMap/Secret to the workloads.WorkloadRefs that should be
+// rolled out when it changes, built from ConfigWatch resources. It lets the
+// controller look targets up directly on a ConfigMap/Secret event instead of
+// listing and scanning every workload.
+type Index struct {
+	mu      sync.RWMutex
+	targets map[sourceKey][]target
+}
+
+// NewIndex returns an empty Index.
+func NewIndex() *Index {
+	return &Index{targets: map[sourceKey][]target{}}
+}
+
+// Put (re)indexes a ConfigWatch, replacing any entries it previously
+// contributed. Call it for both ADDED and MODIFIED events.
+func (idx *Index) Put(cw ConfigWatch) {
+	idx.Delete(cw.Namespace, cw.Name)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	ref := workloads.WorkloadRef{
+		Kind:      cw.Spec.TargetRef.Kind,
+		Name:      cw.Spec.TargetRef.Name,
+		Namespace: cw.Namespace,
+	}
+
+	strategy := cw.Spec.RestartStrategy
+	if strategy == "" {
+		strategy = RestartStrategyRollingRestart
+	}
+
+	for _, source := range cw.Spec.Sources {
+		key := sourceKey{Namespace: cw.Namespace, Kind: source.Kind, Name: source.Name}
+		idx.targets[key] = append(idx.targets[key], target{watchName: cw.Name, ref: ref, keys: source.Keys, strategy: strategy})
+	}
+}
+
+// Delete removes every entry contributed by the ConfigWatch named name in
+// namespace. Call it for DELETED events.
+func (idx *Index) Delete(namespace, name string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for key, targets := range idx.targets {
+		if key.Namespace != namespace {
+			continue
+		}
+
+		kept := targets[:0]
+		for _, t := range targets {
+			if t.watchName != name {
+				kept = append(kept, t)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(idx.targets, key)
+		} else {
+			idx.targets[key] = kept
+		}
+	}
+}
+
+// Lookup returns the targets registered against the ConfigMap/Secret named
+// name in namespace. A target whose ConfigWatch source restricts to
+// specific keys is only returned if allKeys is set or one of changedKeys
+// intersects that restriction.
+func (idx *Index) Lookup(namespace, kind, name string, changedKeys []string, allKeys bool) []Target {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var targets []Target
+	for _, t := range idx.targets[sourceKey{Namespace: namespace, Kind: kind, Name: name}] {
+		if allKeys || len(t.keys) == 0 {
+			targets = append(targets, Target{Ref: t.ref, RestartStrategy: t.strategy})
+			continue
+		}
+
+		for _, key := range changedKeys {
+			if containsString(t.keys, key) {
+				targets = append(targets, Target{Ref: t.ref, RestartStrategy: t.strategy})
+				break
+			}
+		}
+	}
+
+	return targets
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
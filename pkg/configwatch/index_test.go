@@ -0,0 +1,85 @@
+package configwatch
+
+import (
+	"testing"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestIndexLookupRestartStrategy guards against Lookup silently dropping a
+// ConfigWatch's restartStrategy: the index is the only place that strategy
+// exists by the time the reconciler rolls a target out.
+func TestIndexLookupRestartStrategy(t *testing.T) {
+	idx := NewIndex()
+	idx.Put(ConfigWatch{
+		ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "recreate-me"},
+		Spec: ConfigWatchSpec{
+			TargetRef:       TargetRef{Kind: "StatefulSet", Name: "db"},
+			Sources:         []SourceRef{{Kind: "Secret", Name: "db-creds"}},
+			RestartStrategy: RestartStrategyRecreate,
+		},
+	})
+
+	targets := idx.Lookup("default", "Secret", "db-creds", nil, true)
+	if len(targets) != 1 {
+		t.Fatalf("expected 1 target, got %d", len(targets))
+	}
+
+	if targets[0].RestartStrategy != RestartStrategyRecreate {
+		t.Fatalf("expected RestartStrategyRecreate, got %q", targets[0].RestartStrategy)
+	}
+}
+
+// TestIndexLookupDefaultsToRollingRestart covers a ConfigWatch that leaves
+// restartStrategy unset.
+func TestIndexLookupDefaultsToRollingRestart(t *testing.T) {
+	idx := NewIndex()
+	idx.Put(ConfigWatch{
+		ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "default-strategy"},
+		Spec: ConfigWatchSpec{
+			TargetRef: TargetRef{Kind: "Deployment", Name: "web"},
+			Sources:   []SourceRef{{Kind: "ConfigMap", Name: "web-config"}},
+		},
+	})
+
+	targets := idx.Lookup("default", "ConfigMap", "web-config", nil, true)
+	if len(targets) != 1 {
+		t.Fatalf("expected 1 target, got %d", len(targets))
+	}
+
+	if targets[0].RestartStrategy != RestartStrategyRollingRestart {
+		t.Fatalf("expected RestartStrategyRollingRestart, got %q", targets[0].RestartStrategy)
+	}
+}
+
+// TestIndexDeleteScopedToNamespace guards against Delete matching on
+// watchName alone: two ConfigWatches with the same name in different
+// namespaces must not be able to evict each other's entries.
+func TestIndexDeleteScopedToNamespace(t *testing.T) {
+	idx := NewIndex()
+	idx.Put(ConfigWatch{
+		ObjectMeta: meta.ObjectMeta{Namespace: "team-a", Name: "shared-name"},
+		Spec: ConfigWatchSpec{
+			TargetRef: TargetRef{Kind: "Deployment", Name: "web-a"},
+			Sources:   []SourceRef{{Kind: "ConfigMap", Name: "web-config"}},
+		},
+	})
+	idx.Put(ConfigWatch{
+		ObjectMeta: meta.ObjectMeta{Namespace: "team-b", Name: "shared-name"},
+		Spec: ConfigWatchSpec{
+			TargetRef: TargetRef{Kind: "Deployment", Name: "web-b"},
+			Sources:   []SourceRef{{Kind: "ConfigMap", Name: "web-config"}},
+		},
+	})
+
+	idx.Delete("team-a", "shared-name")
+
+	if targets := idx.Lookup("team-a", "ConfigMap", "web-config", nil, true); len(targets) != 0 {
+		t.Fatalf("expected team-a's entry to be deleted, got %v", targets)
+	}
+
+	targets := idx.Lookup("team-b", "ConfigMap", "web-config", nil, true)
+	if len(targets) != 1 || targets[0].Ref.Name != "web-b" {
+		t.Fatalf("expected team-b's entry to survive a same-named delete in team-a, got %v", targets)
+	}
+}
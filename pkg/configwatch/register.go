@@ -0,0 +1,31 @@
+package configwatch
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the API group configmaster's CRD is registered under.
+const GroupName = "configmaster.nlf.io"
+
+// SchemeGroupVersion is the v1alpha1 GroupVersion for ConfigWatch.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// GroupVersionResource is the plural resource used to address ConfigWatch
+// through the dynamic client.
+var GroupVersionResource = SchemeGroupVersion.WithResource("configwatches")
+
+// SchemeBuilder and AddToScheme register ConfigWatch types with a
+// runtime.Scheme, in case a typed client is ever generated for them. The
+// dynamic-client helpers in client.go don't depend on this registration.
+var (
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	AddToScheme   = SchemeBuilder.AddToScheme
+)
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SchemeGroupVersion, &ConfigWatch{}, &ConfigWatchList{})
+	meta.AddToGroupVersion(scheme, SchemeGroupVersion)
+	return nil
+}
@@ -0,0 +1,106 @@
+// Package configwatch implements configmaster's opt-in CRD,
+// configmaster.nlf.io/v1alpha1 ConfigWatch. A ConfigWatch declares which
+// workload should be rolled out when a specific ConfigMap/Secret (and
+// optionally only specific keys of it) changes, so the controller can look
+// targets up in an in-memory Index instead of listing and scanning every
+// workload on every ConfigMap/Secret event.
+package configwatch
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RestartStrategy controls how a ConfigWatch's target is rolled out.
+type RestartStrategy string
+
+const (
+	// RestartStrategyRollingRestart patches the target's pod template
+	// (the default configmaster behavior), letting the workload's own
+	// rollout strategy take it from there.
+	RestartStrategyRollingRestart RestartStrategy = "rollingRestart"
+
+	// RestartStrategyRecreate deletes the target's pods and lets its
+	// controller recreate them, for workloads that don't tolerate a rolling
+	// update.
+	RestartStrategyRecreate RestartStrategy = "recreate"
+)
+
+// TargetRef names the workload a ConfigWatch rolls out.
+type TargetRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// SourceRef names a ConfigMap or Secret a ConfigWatch's target consumes. If
+// Keys is set, only a change to one of those keys triggers a rollout;
+// otherwise any change to the object does.
+type SourceRef struct {
+	Kind string   `json:"kind"`
+	Name string   `json:"name"`
+	Keys []string `json:"keys,omitempty"`
+}
+
+// ConfigWatchSpec is the spec of a ConfigWatch resource.
+type ConfigWatchSpec struct {
+	TargetRef       TargetRef       `json:"targetRef"`
+	Sources         []SourceRef     `json:"sources"`
+	RestartStrategy RestartStrategy `json:"restartStrategy,omitempty"`
+}
+
+// ConfigWatch declares that TargetRef should be rolled out whenever one of
+// Sources changes.
+type ConfigWatch struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ConfigWatchSpec `json:"spec"`
+}
+
+// DeepCopyObject implements runtime.Object. There's no code-gen for this
+// CRD (see Index and the dynamic-client List/Watch helpers), so it's
+// hand-written rather than generated by deepcopy-gen.
+func (in *ConfigWatch) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+
+	out := *in
+	out.ObjectMeta = *in.ObjectMeta.DeepCopy()
+	out.Spec.TargetRef = in.Spec.TargetRef
+
+	if in.Spec.Sources != nil {
+		out.Spec.Sources = make([]SourceRef, len(in.Spec.Sources))
+		for i, source := range in.Spec.Sources {
+			out.Spec.Sources[i] = source
+			if source.Keys != nil {
+				out.Spec.Sources[i].Keys = append([]string(nil), source.Keys...)
+			}
+		}
+	}
+
+	return &out
+}
+
+// ConfigWatchList is a list of ConfigWatch resources.
+type ConfigWatchList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []ConfigWatch `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *ConfigWatchList) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+
+	out := *in
+	out.Items = make([]ConfigWatch, len(in.Items))
+	for i, item := range in.Items {
+		out.Items[i] = *item.DeepCopyObject().(*ConfigWatch)
+	}
+
+	return &out
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewCacheOptions builds the cache.Options for ctrl.Options.Cache, covering
+// configmaster's namespace-scoping modes: cluster-wide (namespaces is
+// empty), a single namespace, or an explicit list of namespaces. selector,
+// if non-nil, additionally restricts which ConfigMaps/Secrets the cache
+// watches and keeps in memory, and composes freely with any of the
+// namespace modes above.
+func NewCacheOptions(namespaces []string, selector labels.Selector) cache.Options {
+	opts := cache.Options{}
+
+	if len(namespaces) > 0 {
+		defaults := make(map[string]cache.Config, len(namespaces))
+		for _, namespace := range namespaces {
+			defaults[namespace] = cache.Config{}
+		}
+
+		opts.DefaultNamespaces = defaults
+	}
+
+	if selector != nil {
+		opts.ByObject = map[client.Object]cache.ByObject{
+			&core.ConfigMap{}: {Label: selector},
+			&core.Secret{}:    {Label: selector},
+		}
+	}
+
+	return opts
+}
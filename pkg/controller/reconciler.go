@@ -0,0 +1,317 @@
+// Package controller ports configmaster's rollout logic onto
+// sigs.k8s.io/controller-runtime, replacing the old bare client-go watches,
+// goroutines, and manual debounce map with a Reconciler driven by a
+// rate-limited workqueue.
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/metadata"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/nlf/configmaster/pkg/configwatch"
+	"github.com/nlf/configmaster/pkg/workloads"
+)
+
+// sourceState tracks one ConfigMap/Secret's debounce window: the
+// resourceVersion last observed, when that change was first seen, and a
+// snapshot of its data as of the start of the window so the keys that
+// changed across the whole window can be computed once it elapses.
+type sourceState struct {
+	resourceVersion string
+	changeTime      time.Time
+	baseline        workloads.DataSnapshot
+	allKeys         bool
+}
+
+// SourceReconciler reconciles both ConfigMaps and Secrets (it's registered
+// with For(&corev1.ConfigMap{}).Watches(&corev1.Secret{}, ...), so a single
+// Reconcile may be asked about either kind) by debouncing rapid-fire
+// changes to the same object and then rolling out whatever references it.
+//
+// The debounce window replaces the old map[ChangeType]*time.Timer, which
+// was read and written from multiple watch goroutines with no mutex. Here
+// state is guarded by mu, and the "wait and see if more changes arrive" step
+// is expressed as RequeueAfter on the workqueue rather than a time.Timer.
+type SourceReconciler struct {
+	Client   client.Client
+	Metadata metadata.Interface // optional; nil disables the metadata-only existence check
+	Registry *workloads.Registry
+	Watches  *configwatch.Index
+	AutoScan bool
+	Delay    time.Duration
+
+	// ResourceSelector restricts AutoScan's Registry.List to workloads
+	// matching this label selector; empty matches everything. It has no
+	// effect when AutoScan is false, since the ConfigWatch index is already
+	// scoped by whatever the ConfigWatch authors chose to target.
+	ResourceSelector string
+
+	mu    sync.Mutex
+	state map[string]*sourceState
+}
+
+// Reconcile implements reconcile.Reconciler.
+func (r *SourceReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	logger := log.FromContext(ctx)
+
+	kind, snapshot, resourceVersion, err := r.fetch(ctx, req.NamespacedName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forget(req.NamespacedName)
+			return reconcile.Result{}, nil
+		}
+
+		return reconcile.Result{}, err
+	}
+
+	changedKeys, allKeys, ready := r.advance(stateKey(kind, req.NamespacedName), resourceVersion, snapshot)
+	if !ready {
+		return reconcile.Result{RequeueAfter: r.Delay}, nil
+	}
+
+	targets, err := r.targets(ctx, req.Namespace, kind, req.Name, changedKeys, allKeys)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// The rollout annotation is a content hash of the source's data rather
+	// than a timestamp, so Patch (via annotationsUnchanged) can skip
+	// workloads that already carry it -- e.g. a kubectl apply of an
+	// unchanged manifest, or a controller rewriting status, no longer
+	// forces a rolling restart.
+	annotationKey := fmt.Sprintf("configmaster/config-hash.%s.%s", kind, req.Name)
+	annotationValue := workloads.ContentHash(snapshot)
+
+	r.rollout(ctx, logger, targets, annotationKey, annotationValue)
+	return reconcile.Result{}, nil
+}
+
+// fetch tries ConfigMap then Secret, since one Reconciler watches both
+// kinds and a NamespacedName alone doesn't say which it is.
+func (r *SourceReconciler) fetch(ctx context.Context, key types.NamespacedName) (string, workloads.DataSnapshot, string, error) {
+	var cm core.ConfigMap
+	err := r.Client.Get(ctx, key, &cm)
+	if err == nil {
+		return "ConfigMap", workloads.ConfigMapSnapshot(cm.Data, cm.BinaryData), cm.ResourceVersion, nil
+	} else if !apierrors.IsNotFound(err) {
+		return "", nil, "", err
+	}
+
+	var secret core.Secret
+	err = r.Client.Get(ctx, key, &secret)
+	if err == nil {
+		return "Secret", workloads.SecretSnapshot(secret.Data), secret.ResourceVersion, nil
+	} else if !apierrors.IsNotFound(err) {
+		return "", nil, "", err
+	}
+
+	return "", nil, "", apierrors.NewNotFound(core.Resource("configmaps"), key.Name)
+}
+
+// advance folds one observation into the debounce window for key. It
+// returns ready=false (and the caller should requeue after r.Delay)
+// whenever the window just started or was just extended by a genuinely new
+// resourceVersion; it returns ready=true, with the keys that changed across
+// the whole window, once r.Delay has passed without a further change.
+func (r *SourceReconciler) advance(key string, resourceVersion string, snapshot workloads.DataSnapshot) ([]string, bool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state == nil {
+		r.state = map[string]*sourceState{}
+	}
+
+	st, ok := r.state[key]
+	if !ok {
+		r.state[key] = &sourceState{
+			resourceVersion: resourceVersion,
+			changeTime:      time.Now(),
+			baseline:        snapshot,
+			allKeys:         true, // first observation ever; can't diff against a prior baseline
+		}
+
+		return nil, false, false
+	}
+
+	if st.resourceVersion != resourceVersion {
+		st.resourceVersion = resourceVersion
+		st.changeTime = time.Now()
+		st.allKeys = false
+		return nil, false, false
+	}
+
+	if time.Since(st.changeTime) < r.Delay {
+		return nil, false, false
+	}
+
+	delete(r.state, key)
+
+	if st.allKeys {
+		return nil, true, true
+	}
+
+	return workloads.ChangedKeys(st.baseline, snapshot), false, true
+}
+
+// forget drops any in-flight debounce state for key, e.g. after it's deleted.
+func (r *SourceReconciler) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.state, stateKey("ConfigMap", key))
+	delete(r.state, stateKey("Secret", key))
+}
+
+func stateKey(kind string, key types.NamespacedName) string {
+	return kind + "/" + key.String()
+}
+
+// targets resolves which workloads should roll out for a change to
+// (namespace, kind, name), and how: either every workload referencing it
+// (AutoScan, always rolled out via the rolling-restart annotation patch) or
+// a direct lookup against the ConfigWatch index, which also carries each
+// target's restartStrategy.
+func (r *SourceReconciler) targets(ctx context.Context, namespace, kind, name string, changedKeys []string, allKeys bool) ([]configwatch.Target, error) {
+	if r.AutoScan {
+		refs, err := r.scan(ctx, namespace, kind, name, changedKeys, allKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		targets := make([]configwatch.Target, len(refs))
+		for i, ref := range refs {
+			targets[i] = configwatch.Target{Ref: ref, RestartStrategy: configwatch.RestartStrategyRollingRestart}
+		}
+
+		return targets, nil
+	}
+
+	return r.Watches.Lookup(namespace, kind, name, changedKeys, allKeys), nil
+}
+
+// scan lists every workload in namespace and returns those whose references
+// (see workloads.References) match kind/name and, if restricted to specific
+// keys, one of changedKeys.
+func (r *SourceReconciler) scan(ctx context.Context, namespace, kind, name string, changedKeys []string, allKeys bool) ([]workloads.WorkloadRef, error) {
+	all, err := r.Registry.List(ctx, namespace, r.ResourceSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []workloads.WorkloadRef
+workloadLoop:
+	for _, workload := range all {
+		for _, ref := range workloads.References(workload.PodTemplateSpec) {
+			if ref.Type != kind || ref.Name != name {
+				continue
+			}
+
+			if allKeys || ref.Keys == nil {
+				refs = append(refs, workload.Ref)
+				continue workloadLoop
+			}
+
+			for _, key := range ref.Keys {
+				if containsString(changedKeys, key) {
+					refs = append(refs, workload.Ref)
+					continue workloadLoop
+				}
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+// rollout rolls out every target, logging and continuing past any single
+// failure. When r.Metadata is set it does a metadata-only existence check
+// first (cheaper than the full Get that Patch/Restart themselves do), so a
+// target that's been deleted since it was indexed is silently skipped
+// instead of producing an error.
+//
+// A target with restartStrategy: recreate is rolled out by deleting its
+// pods directly (workloads.Restarter.Restart) rather than by patching the
+// annotation, for workloads that don't tolerate a rolling update. Restart
+// has no annotation patch of its own to skip a no-op through, so rollout
+// checks the config-hash annotation (Registry.Annotations) before calling
+// Restart, and only records the new hash (Registry.Annotate) once Restart
+// has actually succeeded -- recording it first would mark the workload
+// up to date even if Restart then failed, permanently skipping the retry
+// on every later reconcile. Every other target (the default, and
+// everything AutoScan finds) goes through the annotation patch, which
+// Patch itself skips (changed=false) for any workload that already
+// carries annotationValue.
+func (r *SourceReconciler) rollout(ctx context.Context, logger logr.Logger, targets []configwatch.Target, annotationKey, annotationValue string) {
+	for _, t := range targets {
+		ref := t.Ref
+
+		if r.Metadata != nil {
+			if _, err := workloads.ResourceVersion(ctx, r.Metadata, ref); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+
+				logger.Error(err, "checking workload before rollout", "kind", ref.Kind, "name", ref.Name)
+			}
+		}
+
+		if t.RestartStrategy == configwatch.RestartStrategyRecreate {
+			current, err := r.Registry.Annotations(ctx, ref)
+			if err != nil {
+				logger.Error(err, "checking workload before restart", "kind", ref.Kind, "name", ref.Name)
+				continue
+			}
+
+			if current[annotationKey] == annotationValue {
+				logger.V(1).Info("workload already up to date", "kind", ref.Kind, "name", ref.Name)
+				continue
+			}
+
+			if err := r.Registry.Restart(ctx, ref); err != nil {
+				logger.Error(err, "restarting workload", "kind", ref.Kind, "name", ref.Name)
+				continue
+			}
+
+			if _, err := r.Registry.Annotate(ctx, ref, map[string]string{annotationKey: annotationValue}); err != nil {
+				logger.Error(err, "recording restart hash", "kind", ref.Kind, "name", ref.Name)
+			}
+
+			logger.Info("restarted workload", "kind", ref.Kind, "name", ref.Name)
+			continue
+		}
+
+		changed, err := r.Registry.Patch(ctx, ref, map[string]string{annotationKey: annotationValue})
+		if err != nil {
+			logger.Error(err, "patching workload", "kind", ref.Kind, "name", ref.Name)
+			continue
+		}
+
+		if !changed {
+			logger.V(1).Info("workload already up to date", "kind", ref.Kind, "name", ref.Name)
+			continue
+		}
+
+		logger.Info("patched workload", "kind", ref.Kind, "name", ref.Name)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
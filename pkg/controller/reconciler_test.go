@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlf/configmaster/pkg/workloads"
+)
+
+// TestAdvanceSelectiveKeys guards against the debounce window always
+// reporting allKeys=true: once a window has been extended by a genuine
+// resourceVersion change, it must fall back to diffing baseline vs. the
+// final snapshot so callers can restrict a rollout to the keys that
+// actually changed.
+func TestAdvanceSelectiveKeys(t *testing.T) {
+	r := &SourceReconciler{Delay: 10 * time.Millisecond}
+	key := "ConfigMap/default/test"
+
+	baseline := workloads.DataSnapshot{"a": "1"}
+	if _, _, ready := r.advance(key, "1", baseline); ready {
+		t.Fatalf("expected first observation to start the debounce window, not fire immediately")
+	}
+
+	extended := workloads.DataSnapshot{"a": "1", "b": "2"}
+	if _, _, ready := r.advance(key, "2", extended); ready {
+		t.Fatalf("expected a new resourceVersion to extend the window, not fire immediately")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+
+	changed, allKeys, ready := r.advance(key, "2", extended)
+	if !ready {
+		t.Fatalf("expected the window to fire once the delay elapsed with no further change")
+	}
+
+	if allKeys {
+		t.Fatalf("expected allKeys=false once the window was extended by a real change; selective rollout is dead code otherwise")
+	}
+
+	if len(changed) != 1 || changed[0] != "b" {
+		t.Fatalf("expected changed=[b], got %v", changed)
+	}
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"time"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/metadata"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	"github.com/nlf/configmaster/pkg/configwatch"
+	"github.com/nlf/configmaster/pkg/workloads"
+)
+
+// Options configures the SourceReconciler registered by AddToManager.
+type Options struct {
+	Registry         *workloads.Registry
+	Watches          *configwatch.Index
+	Metadata         metadata.Interface
+	AutoScan         bool
+	Delay            time.Duration
+	ResourceSelector string
+}
+
+// AddToManager registers a SourceReconciler with mgr, watching ConfigMaps
+// as the primary resource and Secrets as a second source feeding the same
+// reconcile loop.
+func AddToManager(mgr ctrl.Manager, opts Options) error {
+	reconciler := &SourceReconciler{
+		Client:           mgr.GetClient(),
+		Metadata:         opts.Metadata,
+		Registry:         opts.Registry,
+		Watches:          opts.Watches,
+		AutoScan:         opts.AutoScan,
+		Delay:            opts.Delay,
+		ResourceSelector: opts.ResourceSelector,
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&core.ConfigMap{}).
+		Watches(&core.Secret{}, &handler.EnqueueRequestForObject{}).
+		Complete(reconciler)
+}
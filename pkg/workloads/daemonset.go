@@ -0,0 +1,161 @@
+package workloads
+
+import (
+	"context"
+	"encoding/json"
+
+	apps "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DaemonSetRestarter is the Restarter for apps/v1 DaemonSets.
+type DaemonSetRestarter struct {
+	Client kubernetes.Interface
+}
+
+// Kind implements Restarter.
+func (d *DaemonSetRestarter) Kind() string {
+	return "DaemonSet"
+}
+
+// List implements Restarter.
+func (d *DaemonSetRestarter) List(ctx context.Context, namespace, labelSelector string) ([]Workload, error) {
+	daemonSets, err := d.Client.AppsV1().DaemonSets(namespace).List(ctx, meta.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]Workload, 0, len(daemonSets.Items))
+	for _, daemonSet := range daemonSets.Items {
+		workloads = append(workloads, Workload{
+			Ref: WorkloadRef{
+				Kind:            d.Kind(),
+				Name:            daemonSet.Name,
+				Namespace:       daemonSet.Namespace,
+				ResourceVersion: daemonSet.ResourceVersion,
+			},
+			PodTemplateSpec: &daemonSet.Spec.Template,
+		})
+	}
+
+	return workloads, nil
+}
+
+// Patch implements Restarter.
+func (d *DaemonSetRestarter) Patch(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	set, err := d.Client.AppsV1().DaemonSets(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{
+		ResourceVersion: ref.ResourceVersion,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if annotationsUnchanged(set.Spec.Template.Annotations, annotations) {
+		return false, nil
+	}
+
+	current, err := json.Marshal(set)
+	if err != nil {
+		return false, err
+	}
+
+	if set.Annotations == nil {
+		set.Annotations = map[string]string{}
+	}
+
+	if set.Spec.Template.Annotations == nil {
+		set.Spec.Template.Annotations = map[string]string{}
+	}
+
+	for key, value := range annotations {
+		set.Annotations[key] = value
+		set.Spec.Template.Annotations[key] = value
+	}
+
+	updated, err := json.Marshal(set)
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(current, updated, apps.DaemonSet{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.Client.AppsV1().DaemonSets(ref.Namespace).Patch(ctx, ref.Name, types.StrategicMergePatchType, patch, meta.PatchOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Annotations implements Restarter.
+func (d *DaemonSetRestarter) Annotations(ctx context.Context, ref WorkloadRef) (map[string]string, error) {
+	set, err := d.Client.AppsV1().DaemonSets(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return set.Annotations, nil
+}
+
+// Annotate implements Restarter.
+func (d *DaemonSetRestarter) Annotate(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	set, err := d.Client.AppsV1().DaemonSets(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	if annotationsUnchanged(set.Annotations, annotations) {
+		return false, nil
+	}
+
+	current, err := json.Marshal(set)
+	if err != nil {
+		return false, err
+	}
+
+	if set.Annotations == nil {
+		set.Annotations = map[string]string{}
+	}
+
+	for key, value := range annotations {
+		set.Annotations[key] = value
+	}
+
+	updated, err := json.Marshal(set)
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(current, updated, apps.DaemonSet{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.Client.AppsV1().DaemonSets(ref.Namespace).Patch(ctx, ref.Name, types.StrategicMergePatchType, patch, meta.PatchOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Restart implements Restarter.
+func (d *DaemonSetRestarter) Restart(ctx context.Context, ref WorkloadRef) error {
+	set, err := d.Client.AppsV1().DaemonSets(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	selector, err := meta.LabelSelectorAsSelector(set.Spec.Selector)
+	if err != nil {
+		return err
+	}
+
+	return d.Client.CoreV1().Pods(ref.Namespace).DeleteCollection(ctx, meta.DeleteOptions{}, meta.ListOptions{LabelSelector: selector.String()})
+}
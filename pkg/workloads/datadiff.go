@@ -0,0 +1,50 @@
+package workloads
+
+// DataSnapshot is a flattened view of a ConfigMap/Secret's Data (and, for
+// ConfigMaps, BinaryData) keyed by data key, used to diff which keys
+// changed between two observations of the same object.
+type DataSnapshot map[string]string
+
+// ConfigMapSnapshot flattens a ConfigMap's Data and BinaryData into a
+// single DataSnapshot.
+func ConfigMapSnapshot(data map[string]string, binaryData map[string][]byte) DataSnapshot {
+	snap := make(DataSnapshot, len(data)+len(binaryData))
+	for k, v := range data {
+		snap[k] = v
+	}
+
+	for k, v := range binaryData {
+		snap[k] = string(v)
+	}
+
+	return snap
+}
+
+// SecretSnapshot flattens a Secret's Data into a DataSnapshot.
+func SecretSnapshot(data map[string][]byte) DataSnapshot {
+	snap := make(DataSnapshot, len(data))
+	for k, v := range data {
+		snap[k] = string(v)
+	}
+
+	return snap
+}
+
+// ChangedKeys returns the keys whose value differs between previous and
+// current, including keys that were added or removed.
+func ChangedKeys(previous, current DataSnapshot) []string {
+	var keys []string
+	for k, v := range current {
+		if pv, ok := previous[k]; !ok || pv != v {
+			keys = append(keys, k)
+		}
+	}
+
+	for k := range previous {
+		if _, ok := current[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,162 @@
+package workloads
+
+import (
+	"context"
+	"encoding/json"
+
+	apps "k8s.io/api/apps/v1beta2"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DeploymentRestarter is the Restarter for apps/v1beta2 Deployments, the
+// original (and still default) workload kind configmaster rolls out.
+type DeploymentRestarter struct {
+	Client kubernetes.Interface
+}
+
+// Kind implements Restarter.
+func (d *DeploymentRestarter) Kind() string {
+	return "Deployment"
+}
+
+// List implements Restarter.
+func (d *DeploymentRestarter) List(ctx context.Context, namespace, labelSelector string) ([]Workload, error) {
+	deployments, err := d.Client.AppsV1beta2().Deployments(namespace).List(ctx, meta.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]Workload, 0, len(deployments.Items))
+	for _, deployment := range deployments.Items {
+		workloads = append(workloads, Workload{
+			Ref: WorkloadRef{
+				Kind:            d.Kind(),
+				Name:            deployment.Name,
+				Namespace:       deployment.Namespace,
+				ResourceVersion: deployment.ResourceVersion,
+			},
+			PodTemplateSpec: &deployment.Spec.Template,
+		})
+	}
+
+	return workloads, nil
+}
+
+// Patch implements Restarter.
+func (d *DeploymentRestarter) Patch(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	dep, err := d.Client.AppsV1beta2().Deployments(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{
+		ResourceVersion: ref.ResourceVersion,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if annotationsUnchanged(dep.Spec.Template.Annotations, annotations) {
+		return false, nil
+	}
+
+	current, err := json.Marshal(dep)
+	if err != nil {
+		return false, err
+	}
+
+	if dep.Annotations == nil {
+		dep.Annotations = map[string]string{}
+	}
+
+	if dep.Spec.Template.Annotations == nil {
+		dep.Spec.Template.Annotations = map[string]string{}
+	}
+
+	for key, value := range annotations {
+		dep.Annotations[key] = value
+		dep.Spec.Template.Annotations[key] = value
+	}
+
+	updated, err := json.Marshal(dep)
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(current, updated, apps.Deployment{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.Client.AppsV1beta2().Deployments(ref.Namespace).Patch(ctx, ref.Name, types.StrategicMergePatchType, patch, meta.PatchOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Annotations implements Restarter.
+func (d *DeploymentRestarter) Annotations(ctx context.Context, ref WorkloadRef) (map[string]string, error) {
+	dep, err := d.Client.AppsV1beta2().Deployments(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return dep.Annotations, nil
+}
+
+// Annotate implements Restarter.
+func (d *DeploymentRestarter) Annotate(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	dep, err := d.Client.AppsV1beta2().Deployments(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	if annotationsUnchanged(dep.Annotations, annotations) {
+		return false, nil
+	}
+
+	current, err := json.Marshal(dep)
+	if err != nil {
+		return false, err
+	}
+
+	if dep.Annotations == nil {
+		dep.Annotations = map[string]string{}
+	}
+
+	for key, value := range annotations {
+		dep.Annotations[key] = value
+	}
+
+	updated, err := json.Marshal(dep)
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(current, updated, apps.Deployment{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.Client.AppsV1beta2().Deployments(ref.Namespace).Patch(ctx, ref.Name, types.StrategicMergePatchType, patch, meta.PatchOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Restart implements Restarter.
+func (d *DeploymentRestarter) Restart(ctx context.Context, ref WorkloadRef) error {
+	dep, err := d.Client.AppsV1beta2().Deployments(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	selector, err := meta.LabelSelectorAsSelector(dep.Spec.Selector)
+	if err != nil {
+		return err
+	}
+
+	return d.Client.CoreV1().Pods(ref.Namespace).DeleteCollection(ctx, meta.DeleteOptions{}, meta.ListOptions{LabelSelector: selector.String()})
+}
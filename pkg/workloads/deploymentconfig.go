@@ -0,0 +1,176 @@
+//go:build openshift
+// +build openshift
+
+package workloads
+
+import (
+	"context"
+	"encoding/json"
+
+	osapps "github.com/openshift/api/apps/v1"
+	osclient "github.com/openshift/client-go/apps/clientset/versioned"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DeploymentConfigRestarter is the Restarter for OpenShift's
+// apps.openshift.io/v1 DeploymentConfig. It is only compiled in with the
+// "openshift" build tag, since the OpenShift client is not vendored into
+// vanilla Kubernetes builds of configmaster.
+type DeploymentConfigRestarter struct {
+	Client osclient.Interface
+
+	// CoreClient deletes the pods for Restart; DeploymentConfigs are rolled
+	// out through osclient, but their pods are ordinary core/v1 Pods.
+	CoreClient kubernetes.Interface
+}
+
+// Kind implements Restarter.
+func (d *DeploymentConfigRestarter) Kind() string {
+	return "DeploymentConfig"
+}
+
+// List implements Restarter.
+func (d *DeploymentConfigRestarter) List(ctx context.Context, namespace, labelSelector string) ([]Workload, error) {
+	configs, err := d.Client.AppsV1().DeploymentConfigs(namespace).List(ctx, meta.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]Workload, 0, len(configs.Items))
+	for _, dc := range configs.Items {
+		if dc.Spec.Template == nil {
+			continue
+		}
+
+		workloads = append(workloads, Workload{
+			Ref: WorkloadRef{
+				Kind:            d.Kind(),
+				Name:            dc.Name,
+				Namespace:       dc.Namespace,
+				ResourceVersion: dc.ResourceVersion,
+			},
+			PodTemplateSpec: dc.Spec.Template,
+		})
+	}
+
+	return workloads, nil
+}
+
+// Patch implements Restarter. DeploymentConfigs roll out through the
+// OpenShift apps client rather than the core Kubernetes apps client, so this
+// goes through osclient instead of kubernetes.Interface.
+func (d *DeploymentConfigRestarter) Patch(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	dc, err := d.Client.AppsV1().DeploymentConfigs(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{
+		ResourceVersion: ref.ResourceVersion,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if annotationsUnchanged(dc.Spec.Template.Annotations, annotations) {
+		return false, nil
+	}
+
+	current, err := json.Marshal(dc)
+	if err != nil {
+		return false, err
+	}
+
+	if dc.Annotations == nil {
+		dc.Annotations = map[string]string{}
+	}
+
+	if dc.Spec.Template.Annotations == nil {
+		dc.Spec.Template.Annotations = map[string]string{}
+	}
+
+	for key, value := range annotations {
+		dc.Annotations[key] = value
+		dc.Spec.Template.Annotations[key] = value
+	}
+
+	updated, err := json.Marshal(dc)
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(current, updated, osapps.DeploymentConfig{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.Client.AppsV1().DeploymentConfigs(ref.Namespace).Patch(ctx, ref.Name, types.StrategicMergePatchType, patch, meta.PatchOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Annotations implements Restarter.
+func (d *DeploymentConfigRestarter) Annotations(ctx context.Context, ref WorkloadRef) (map[string]string, error) {
+	dc, err := d.Client.AppsV1().DeploymentConfigs(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return dc.Annotations, nil
+}
+
+// Annotate implements Restarter.
+func (d *DeploymentConfigRestarter) Annotate(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	dc, err := d.Client.AppsV1().DeploymentConfigs(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	if annotationsUnchanged(dc.Annotations, annotations) {
+		return false, nil
+	}
+
+	current, err := json.Marshal(dc)
+	if err != nil {
+		return false, err
+	}
+
+	if dc.Annotations == nil {
+		dc.Annotations = map[string]string{}
+	}
+
+	for key, value := range annotations {
+		dc.Annotations[key] = value
+	}
+
+	updated, err := json.Marshal(dc)
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(current, updated, osapps.DeploymentConfig{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.Client.AppsV1().DeploymentConfigs(ref.Namespace).Patch(ctx, ref.Name, types.StrategicMergePatchType, patch, meta.PatchOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Restart implements Restarter. DeploymentConfig's selector is a plain
+// map[string]string rather than a *metav1.LabelSelector.
+func (d *DeploymentConfigRestarter) Restart(ctx context.Context, ref WorkloadRef) error {
+	dc, err := d.Client.AppsV1().DeploymentConfigs(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	selector := labels.SelectorFromSet(dc.Spec.Selector).String()
+	return d.CoreClient.CoreV1().Pods(ref.Namespace).DeleteCollection(ctx, meta.DeleteOptions{}, meta.ListOptions{LabelSelector: selector})
+}
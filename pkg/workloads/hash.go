@@ -0,0 +1,31 @@
+package workloads
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// ContentHash returns a stable SHA-256 hex digest of snapshot's keys and
+// values, suitable for a configmaster/config-hash.<kind>.<name> annotation.
+// Keys are sorted before marshaling so the same data always hashes the
+// same way regardless of map iteration order.
+func ContentHash(snapshot DataSnapshot) string {
+	keys := make([]string, 0, len(snapshot))
+	for k := range snapshot {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	ordered := make([][2]string, len(keys))
+	for i, k := range keys {
+		ordered[i] = [2]string{k, snapshot[k]}
+	}
+
+	// Marshal errors are impossible here: ordered is just nested strings.
+	data, _ := json.Marshal(ordered)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
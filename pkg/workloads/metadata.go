@@ -0,0 +1,39 @@
+package workloads
+
+import (
+	"context"
+	"fmt"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata"
+)
+
+// gvrsByKind maps a WorkloadRef.Kind to the GroupVersionResource used for
+// metadata-only lookups. It only needs to cover whichever Restarters the
+// controller can resolve a metadata-only check for.
+var gvrsByKind = map[string]schema.GroupVersionResource{
+	"Deployment":       {Group: "apps", Version: "v1beta2", Resource: "deployments"},
+	"StatefulSet":      {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"DaemonSet":        {Group: "apps", Version: "v1", Resource: "daemonsets"},
+	"DeploymentConfig": {Group: "apps.openshift.io", Version: "v1", Resource: "deploymentconfigs"},
+}
+
+// ResourceVersion does a metadata-only Get (PartialObjectMetadata) for ref,
+// returning its current ResourceVersion without fetching the full spec.
+// The controller uses this to confirm a ConfigWatch target still exists
+// before patching it, which is far cheaper on apiserver memory/network than
+// a full Get when all that's needed is identity, not the pod spec.
+func ResourceVersion(ctx context.Context, metadataClient metadata.Interface, ref WorkloadRef) (string, error) {
+	gvr, ok := gvrsByKind[ref.Kind]
+	if !ok {
+		return "", fmt.Errorf("no metadata-only mapping registered for kind %q", ref.Kind)
+	}
+
+	obj, err := metadataClient.Resource(gvr).Namespace(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return obj.ResourceVersion, nil
+}
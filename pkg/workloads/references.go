@@ -0,0 +1,88 @@
+package workloads
+
+import (
+	core "k8s.io/api/core/v1"
+)
+
+// Reference describes one ConfigMap or Secret consumed by a workload's pod
+// template. A nil Keys means every key of the object is consumed (envFrom,
+// or a volume/projection mounted without an items filter), so any change to
+// the object matters; a non-nil Keys means only those keys are consumed, so
+// a change to an unrelated key should not trigger a rollout.
+type Reference struct {
+	Type string // "ConfigMap" or "Secret"
+	Name string
+	Keys []string
+}
+
+// References walks spec's containers and volumes for every ConfigMap and
+// Secret it consumes: EnvFrom, Env[].ValueFrom.*KeyRef, ConfigMap/Secret
+// volumes, and projected volume sources.
+func References(spec *core.PodTemplateSpec) []Reference {
+	var refs []Reference
+
+	for _, container := range spec.Spec.Containers {
+		for _, env := range container.EnvFrom {
+			if env.ConfigMapRef != nil {
+				refs = append(refs, Reference{Type: "ConfigMap", Name: env.ConfigMapRef.Name})
+			}
+
+			if env.SecretRef != nil {
+				refs = append(refs, Reference{Type: "Secret", Name: env.SecretRef.Name})
+			}
+		}
+
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				refs = append(refs, Reference{Type: "ConfigMap", Name: ref.Name, Keys: []string{ref.Key}})
+			}
+
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				refs = append(refs, Reference{Type: "Secret", Name: ref.Name, Keys: []string{ref.Key}})
+			}
+		}
+	}
+
+	for _, volume := range spec.Spec.Volumes {
+		if cm := volume.ConfigMap; cm != nil {
+			refs = append(refs, Reference{Type: "ConfigMap", Name: cm.Name, Keys: itemKeys(cm.Items)})
+		}
+
+		if secret := volume.Secret; secret != nil {
+			refs = append(refs, Reference{Type: "Secret", Name: secret.SecretName, Keys: itemKeys(secret.Items)})
+		}
+
+		if projected := volume.Projected; projected != nil {
+			for _, source := range projected.Sources {
+				if cm := source.ConfigMap; cm != nil {
+					refs = append(refs, Reference{Type: "ConfigMap", Name: cm.Name, Keys: itemKeys(cm.Items)})
+				}
+
+				if secret := source.Secret; secret != nil {
+					refs = append(refs, Reference{Type: "Secret", Name: secret.Name, Keys: itemKeys(secret.Items)})
+				}
+			}
+		}
+	}
+
+	return refs
+}
+
+// itemKeys extracts the keys named by a volume's items filter, or nil if no
+// filter is set, meaning every key in the ConfigMap/Secret is projected.
+func itemKeys(items []core.KeyToPath) []string {
+	if len(items) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+
+	return keys
+}
@@ -0,0 +1,161 @@
+package workloads
+
+import (
+	"context"
+	"encoding/json"
+
+	apps "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// StatefulSetRestarter is the Restarter for apps/v1 StatefulSets.
+type StatefulSetRestarter struct {
+	Client kubernetes.Interface
+}
+
+// Kind implements Restarter.
+func (s *StatefulSetRestarter) Kind() string {
+	return "StatefulSet"
+}
+
+// List implements Restarter.
+func (s *StatefulSetRestarter) List(ctx context.Context, namespace, labelSelector string) ([]Workload, error) {
+	statefulSets, err := s.Client.AppsV1().StatefulSets(namespace).List(ctx, meta.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]Workload, 0, len(statefulSets.Items))
+	for _, statefulSet := range statefulSets.Items {
+		workloads = append(workloads, Workload{
+			Ref: WorkloadRef{
+				Kind:            s.Kind(),
+				Name:            statefulSet.Name,
+				Namespace:       statefulSet.Namespace,
+				ResourceVersion: statefulSet.ResourceVersion,
+			},
+			PodTemplateSpec: &statefulSet.Spec.Template,
+		})
+	}
+
+	return workloads, nil
+}
+
+// Patch implements Restarter.
+func (s *StatefulSetRestarter) Patch(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	set, err := s.Client.AppsV1().StatefulSets(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{
+		ResourceVersion: ref.ResourceVersion,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if annotationsUnchanged(set.Spec.Template.Annotations, annotations) {
+		return false, nil
+	}
+
+	current, err := json.Marshal(set)
+	if err != nil {
+		return false, err
+	}
+
+	if set.Annotations == nil {
+		set.Annotations = map[string]string{}
+	}
+
+	if set.Spec.Template.Annotations == nil {
+		set.Spec.Template.Annotations = map[string]string{}
+	}
+
+	for key, value := range annotations {
+		set.Annotations[key] = value
+		set.Spec.Template.Annotations[key] = value
+	}
+
+	updated, err := json.Marshal(set)
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(current, updated, apps.StatefulSet{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.Client.AppsV1().StatefulSets(ref.Namespace).Patch(ctx, ref.Name, types.StrategicMergePatchType, patch, meta.PatchOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Annotations implements Restarter.
+func (s *StatefulSetRestarter) Annotations(ctx context.Context, ref WorkloadRef) (map[string]string, error) {
+	set, err := s.Client.AppsV1().StatefulSets(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return set.Annotations, nil
+}
+
+// Annotate implements Restarter.
+func (s *StatefulSetRestarter) Annotate(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	set, err := s.Client.AppsV1().StatefulSets(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	if annotationsUnchanged(set.Annotations, annotations) {
+		return false, nil
+	}
+
+	current, err := json.Marshal(set)
+	if err != nil {
+		return false, err
+	}
+
+	if set.Annotations == nil {
+		set.Annotations = map[string]string{}
+	}
+
+	for key, value := range annotations {
+		set.Annotations[key] = value
+	}
+
+	updated, err := json.Marshal(set)
+	if err != nil {
+		return false, err
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(current, updated, apps.StatefulSet{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.Client.AppsV1().StatefulSets(ref.Namespace).Patch(ctx, ref.Name, types.StrategicMergePatchType, patch, meta.PatchOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Restart implements Restarter.
+func (s *StatefulSetRestarter) Restart(ctx context.Context, ref WorkloadRef) error {
+	set, err := s.Client.AppsV1().StatefulSets(ref.Namespace).Get(ctx, ref.Name, meta.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	selector, err := meta.LabelSelectorAsSelector(set.Spec.Selector)
+	if err != nil {
+		return err
+	}
+
+	return s.Client.CoreV1().Pods(ref.Namespace).DeleteCollection(ctx, meta.DeleteOptions{}, meta.ListOptions{LabelSelector: selector.String()})
+}
@@ -0,0 +1,162 @@
+// Package workloads generalizes configmaster's rollout logic across the
+// different kinds of workload that can reference a ConfigMap or Secret.
+// A Deployment was the only kind configmaster originally understood;
+// Restarter lets StatefulSets, DaemonSets, and (optionally) OpenShift
+// DeploymentConfigs share the same debounce/patch plumbing.
+package workloads
+
+import (
+	"context"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+)
+
+// WorkloadRef identifies a single workload that may need to be rolled out.
+// It replaces the old Deployment-only key so the controller's rollout path
+// can handle any kind of workload.
+type WorkloadRef struct {
+	Kind            string
+	Name            string
+	Namespace       string
+	ResourceVersion string
+}
+
+// Workload is a kind-agnostic view of a workload: just enough to scan for
+// ConfigMap/Secret references and to queue a rollout.
+type Workload struct {
+	Ref             WorkloadRef
+	PodTemplateSpec *core.PodTemplateSpec
+}
+
+// Restarter knows how to list and patch one kind of workload. Implementations
+// live in per-kind files (deployment.go, statefulset.go, ...) and are
+// registered with a Registry so the controller can treat every kind
+// uniformly.
+type Restarter interface {
+	// Kind returns the WorkloadRef.Kind this Restarter handles, e.g. "Deployment".
+	Kind() string
+
+	// List returns every workload of this kind in namespace, with just the
+	// pod template spec needed to scan for ConfigMap/Secret references.
+	// labelSelector restricts the result the same way it would a kubectl
+	// get -l; an empty string matches everything.
+	List(ctx context.Context, namespace, labelSelector string) ([]Workload, error)
+
+	// Patch applies annotations to the pod template (and the workload
+	// itself) identified by ref, triggering a rollout, and reports whether
+	// it actually did so. It returns changed=false, err=nil without
+	// patching when every annotation already matches the workload's
+	// current value (the config-hash annotation is unchanged, so there's
+	// nothing new to roll out). A ref that no longer exists is reported as
+	// the Get's NotFound error.
+	Patch(ctx context.Context, ref WorkloadRef, annotations map[string]string) (changed bool, err error)
+
+	// Annotate applies annotations to the workload itself only, leaving its
+	// pod template untouched, and reports whether it actually did so (the
+	// same changed/no-op contract as Patch). Restart doesn't go through an
+	// annotation patch the way a rolling restart does, so the controller
+	// calls Annotate after a successful Restart to record the config-hash
+	// annotation that lets it skip a redundant Restart once that hash has
+	// already been applied.
+	Annotate(ctx context.Context, ref WorkloadRef, annotations map[string]string) (changed bool, err error)
+
+	// Annotations returns the workload's own current annotations (not its
+	// pod template's). The controller uses it as a read-only check before
+	// Restart, so recording the config-hash annotation (via Annotate) can
+	// happen strictly after Restart succeeds without also restarting on
+	// every reconcile to find out whether that's necessary.
+	Annotations(ctx context.Context, ref WorkloadRef) (map[string]string, error)
+
+	// Restart rolls ref out by deleting its pods directly rather than
+	// patching the pod template, for workloads whose ConfigWatch specifies
+	// restartStrategy: recreate because they don't tolerate a rolling
+	// update. The workload's own controller (ReplicaSet, StatefulSet
+	// controller, ...) recreates the pods against the current template.
+	Restart(ctx context.Context, ref WorkloadRef) error
+}
+
+// Registry is an ordered set of Restarters, one per kind. The controller
+// dispatches rollouts to it by kind and, in AutoScan mode, lists it on every
+// ConfigMap/Secret change.
+type Registry struct {
+	restarters []Restarter
+}
+
+// NewRegistry builds a Registry from the given Restarters.
+func NewRegistry(restarters ...Restarter) *Registry {
+	return &Registry{restarters: restarters}
+}
+
+// List returns every workload across every registered kind in namespace
+// matching labelSelector (empty matches everything).
+func (r *Registry) List(ctx context.Context, namespace, labelSelector string) ([]Workload, error) {
+	var all []Workload
+	for _, restarter := range r.restarters {
+		workloads, err := restarter.List(ctx, namespace, labelSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, workloads...)
+	}
+
+	return all, nil
+}
+
+// Patch dispatches to the Restarter registered for ref.Kind.
+func (r *Registry) Patch(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	for _, restarter := range r.restarters {
+		if restarter.Kind() == ref.Kind {
+			return restarter.Patch(ctx, ref, annotations)
+		}
+	}
+
+	return false, fmt.Errorf("no restarter registered for kind %q", ref.Kind)
+}
+
+// Annotate dispatches to the Restarter registered for ref.Kind.
+func (r *Registry) Annotate(ctx context.Context, ref WorkloadRef, annotations map[string]string) (bool, error) {
+	for _, restarter := range r.restarters {
+		if restarter.Kind() == ref.Kind {
+			return restarter.Annotate(ctx, ref, annotations)
+		}
+	}
+
+	return false, fmt.Errorf("no restarter registered for kind %q", ref.Kind)
+}
+
+// Annotations dispatches to the Restarter registered for ref.Kind.
+func (r *Registry) Annotations(ctx context.Context, ref WorkloadRef) (map[string]string, error) {
+	for _, restarter := range r.restarters {
+		if restarter.Kind() == ref.Kind {
+			return restarter.Annotations(ctx, ref)
+		}
+	}
+
+	return nil, fmt.Errorf("no restarter registered for kind %q", ref.Kind)
+}
+
+// Restart dispatches to the Restarter registered for ref.Kind.
+func (r *Registry) Restart(ctx context.Context, ref WorkloadRef) error {
+	for _, restarter := range r.restarters {
+		if restarter.Kind() == ref.Kind {
+			return restarter.Restart(ctx, ref)
+		}
+	}
+
+	return fmt.Errorf("no restarter registered for kind %q", ref.Kind)
+}
+
+// annotationsUnchanged reports whether every key in desired already has the
+// same value in existing, meaning a patch applying desired would be a
+// no-op.
+func annotationsUnchanged(existing, desired map[string]string) bool {
+	for k, v := range desired {
+		if existing[k] != v {
+			return false
+		}
+	}
+
+	return true
+}